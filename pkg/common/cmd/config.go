@@ -0,0 +1,278 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	"github.com/openimsdk/chat/pkg/common/config"
+	disetcd "github.com/openimsdk/chat/pkg/common/kdisc/etcd"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/utils/runtimeenv"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigCmd adds `<processName> config validate|push|pull`, letting
+// operators inspect and manage config-center contents without invoking
+// etcdctl (or the Consul/Kubernetes/Nacos equivalent) directly.
+func newConfigCmd(r *RootCmd) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and manage the " + r.processName + " config center",
+	}
+	configCmd.AddCommand(newConfigValidateCmd(r))
+	configCmd.AddCommand(newConfigPushCmd(r))
+	configCmd.AddCommand(newConfigPullCmd(r))
+	configCmd.AddCommand(newConfigMigrateEncryptCmd(r))
+	return configCmd
+}
+
+// newConfigValidateCmd runs the same load path as initializeConfiguration
+// and updateConfigFromStore but stops before starting any server, printing
+// a diff between file-sourced and config-center-sourced values and failing
+// on any `validate` struct-tag violation config.Validate finds. None of the
+// config structs this series registers via WithConfigMap carry validate
+// tags yet, so today this only catches a violation once a service starts
+// tagging its fields - it's not yet a general schema check for every
+// config file. It relies on the inherited PersistentPreRunE having already
+// called initConfigStore once; calling it again here would hand out a
+// second connection and orphan whatever watch/Closer the first one set up.
+func newConfigValidateCmd(r *RootCmd) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Load config from file and the config center, print the diff, and fail on any validate-tag violation",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmdOpts := r.applyOptions(r.configOpts...)
+			if err := r.initializeConfiguration(cmd, cmdOpts); err != nil {
+				return err
+			}
+			fileValues := snapshotConfigValues(r, cmdOpts)
+			if _, err := r.updateConfigFromStore(cmdOpts, true); err != nil {
+				return err
+			}
+			storeValues := snapshotConfigValues(r, cmdOpts)
+			printConfigDiff(cmd, fileValues, storeValues)
+
+			for configFileName, configStruct := range cmdOpts.configMap {
+				if err := config.Validate(configStruct); err != nil {
+					return errs.WrapMsg(err, "schema violation in "+configFileName)
+				}
+			}
+			if err := config.Validate(&r.log); err != nil {
+				return errs.WrapMsg(err, "schema violation in "+config.LogConfigFileName)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "config OK")
+			return nil
+		},
+	}
+}
+
+func snapshotConfigValues(r *RootCmd, cmdOpts *CmdOpts) map[string]string {
+	values := make(map[string]string, len(cmdOpts.configMap)+1)
+	for configFileName, configStruct := range cmdOpts.configMap {
+		values[configFileName] = marshalForDiff(configStruct)
+	}
+	values[config.LogConfigFileName] = marshalForDiff(&r.log)
+	return values
+}
+
+func marshalForDiff(configStruct any) string {
+	data, err := json.Marshal(configStruct)
+	if err != nil {
+		return fmt.Sprintf("<marshal error: %v>", err)
+	}
+	return string(data)
+}
+
+func printConfigDiff(cmd *cobra.Command, before, after map[string]string) {
+	for configFileName, afterVal := range after {
+		if before[configFileName] != afterVal {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s differs between file and config center:\n  file:   %s\n  center: %s\n",
+				configFileName, before[configFileName], afterVal)
+		}
+	}
+}
+
+// newConfigPushCmd parses a local config file the same way the server
+// would at startup and writes the result to the config center as JSON. It
+// relies on the inherited PersistentPreRunE having already called
+// initConfigStore once; see newConfigValidateCmd's doc comment.
+func newConfigPushCmd(r *RootCmd) *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Parse a local config file and write it to the config center",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if r.configStore == nil {
+				return errs.New("no config store is configured for this discovery backend").Wrap()
+			}
+			if err := r.initSecretCodec(); err != nil {
+				return err
+			}
+			directory, configFileName := filepath.Split(file)
+			cmdOpts := r.applyOptions(r.configOpts...)
+			configStruct, err := newConfigStructFor(configFileName, cmdOpts)
+			if err != nil {
+				return err
+			}
+			err = config.Load(directory, configFileName, config.EnvPrefixMap[configFileName],
+				runtimeenv.PrintRuntimeEnvironment(), configStruct)
+			if err != nil {
+				return err
+			}
+			if err := config.Validate(configStruct); err != nil {
+				return errs.WrapMsg(err, "schema violation in "+configFileName)
+			}
+			data, err := config.EncodeSecretFields(cmd.Context(), r.secretCodec, configStruct)
+			if err != nil {
+				return err
+			}
+			return r.configStore.Put(cmd.Context(), configFileName, data)
+		},
+	}
+	cmd.Flags().StringVar(&file, "file", "", "path to the config file to push, e.g. config/share.yml")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// newConfigPullCmd prints the raw JSON currently stored in the config
+// center for a config file name. It relies on the inherited
+// PersistentPreRunE having already called initConfigStore once; see
+// newConfigValidateCmd's doc comment.
+func newConfigPullCmd(r *RootCmd) *cobra.Command {
+	var configFileName string
+	cmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Print a config center value by config file name",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if r.configStore == nil {
+				return errs.New("no config store is configured for this discovery backend").Wrap()
+			}
+			data, err := r.configStore.Get(cmd.Context(), configFileName)
+			if err != nil {
+				return err
+			}
+			if data == nil {
+				return errs.New("no value found for " + configFileName).Wrap()
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&configFileName, "name", "", "config file name (config center key) to pull, e.g. share.yml")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+// migrateFromCodecEnvVar selects the codec "config migrate-encrypt" uses to
+// decrypt existing config-center values before re-encrypting them under
+// secretCodecEnvVar's codec. Unset or "none" assumes the stored values were
+// never encrypted (the pre-encryption default), which is still the correct
+// choice to undo EncodeSecretFields' base64 wrapping even for plaintext
+// secret fields.
+const migrateFromCodecEnvVar = "CHAT_CONFIG_SECRET_CODEC_FROM"
+
+// newConfigMigrateEncryptCmd re-encrypts every config key already stored
+// in the config center under the codec selected by CHAT_CONFIG_SECRET_CODEC,
+// for operators turning encryption on (or rotating codecs) after running
+// with a different one. Existing values are decrypted with the codec named
+// by CHAT_CONFIG_SECRET_CODEC_FROM first, so rotating codecs - or re-running
+// the command by mistake - re-encrypts the real plaintext instead of
+// wrapping the previous ciphertext a second time. When the backend is etcd
+// the rewrite happens in a single transaction so it either fully lands or
+// fully rolls back; other backends fall back to writing one key at a time.
+// It relies on the inherited PersistentPreRunE having already called
+// initConfigStore once; see newConfigValidateCmd's doc comment.
+func newConfigMigrateEncryptCmd(r *RootCmd) *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate-encrypt",
+		Short: "Re-encrypt existing config-center values with the configured secret codec",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if r.configStore == nil {
+				return errs.New("no config store is configured for this discovery backend").Wrap()
+			}
+			if err := r.initSecretCodec(); err != nil {
+				return err
+			}
+			fromCodec, err := secretCodecFromEnv(migrateFromCodecEnvVar)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			cmdOpts := r.applyOptions(r.configOpts...)
+
+			configFileNames := make([]string, 0, len(cmdOpts.configMap)+1)
+			for configFileName := range cmdOpts.configMap {
+				configFileNames = append(configFileNames, configFileName)
+			}
+			configFileNames = append(configFileNames, config.LogConfigFileName)
+
+			reencrypted := make(map[string][]byte, len(configFileNames))
+			for _, configFileName := range configFileNames {
+				configStruct, err := newConfigStructFor(configFileName, cmdOpts)
+				if err != nil {
+					return err
+				}
+				data, err := r.configStore.Get(ctx, configFileName)
+				if err != nil {
+					return err
+				}
+				if data == nil {
+					continue
+				}
+				if err := config.DecodeSecretFields(ctx, fromCodec, data, configStruct); err != nil {
+					return errs.WrapMsg(err, "failed to decrypt existing value for "+configFileName+" with "+migrateFromCodecEnvVar)
+				}
+				encoded, err := config.EncodeSecretFields(ctx, r.secretCodec, configStruct)
+				if err != nil {
+					return err
+				}
+				reencrypted[configFileName] = encoded
+			}
+
+			if etcdStore, ok := r.configStore.(*disetcd.ConfigStore); ok {
+				if err := etcdStore.PutAll(ctx, reencrypted); err != nil {
+					return err
+				}
+			} else {
+				for configFileName, data := range reencrypted {
+					if err := r.configStore.Put(ctx, configFileName, data); err != nil {
+						return err
+					}
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "migrated %d config keys\n", len(reencrypted))
+			return nil
+		},
+	}
+}
+
+// newConfigStructFor returns a fresh zero-value config struct matching
+// configFileName, mirroring whatever type the service registered it with
+// via WithConfigMap.
+func newConfigStructFor(configFileName string, cmdOpts *CmdOpts) (any, error) {
+	if configFileName == config.LogConfigFileName {
+		return &config.Log{}, nil
+	}
+	existing, ok := cmdOpts.configMap[configFileName]
+	if !ok {
+		return nil, errs.New("unknown config file name " + configFileName).Wrap()
+	}
+	return reflect.New(reflect.TypeOf(existing).Elem()).Interface(), nil
+}
@@ -16,15 +16,19 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/openimsdk/chat/pkg/common/config"
 	"github.com/openimsdk/chat/pkg/common/kdisc"
 	disetcd "github.com/openimsdk/chat/pkg/common/kdisc/etcd"
 	"github.com/openimsdk/chat/version"
-	"github.com/openimsdk/tools/discovery/etcd"
-	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
@@ -33,15 +37,53 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// disableConfigWatchFlag lets operators opt back into the pre-watch
+// behaviour of loading the config center once and never looking at it again.
+const disableConfigWatchFlag = "disable-config-watch"
+
+// shutdownTimeoutFlag bounds how long Execute waits for registered Closer
+// hooks to drain after SIGINT/SIGTERM cancels the root context.
+const shutdownTimeoutFlag = "shutdown-timeout"
+
+const defaultShutdownTimeout = 15 * time.Second
+
+// Closer is implemented by long-lived resources a service hands to
+// RegisterCloser - HTTP servers, gRPC servers, DB pools - so Execute can
+// give them a chance to drain before the process exits.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// CloserFunc adapts a plain function to Closer.
+type CloserFunc func(ctx context.Context) error
+
+func (f CloserFunc) Close(ctx context.Context) error {
+	return f(ctx)
+}
+
 type RootCmd struct {
-	Command        cobra.Command
-	processName    string
-	port           int
-	prometheusPort int
-	log            config.Log
-	index          int
-	configPath     string
-	etcdClient     *clientv3.Client
+	Command             cobra.Command
+	processName         string
+	port                int
+	prometheusPort      int
+	log                 config.Log
+	index               int
+	configPath          string
+	loggerPrefixName    string
+	configOpts          []func(*CmdOpts)
+	configStore         kdisc.ConfigStore
+	secretCodec         config.SecretCodec
+	configCenterEnabled bool
+	configMu            sync.RWMutex
+	lastSnapshots       map[string]any
+	closers             []Closer
+}
+
+// RegisterCloser adds c to the set of resources Execute drains on
+// SIGINT/SIGTERM before returning. Call it from a RunE once the resource
+// (server, pool, watcher) has actually started.
+func (r *RootCmd) RegisterCloser(c Closer) {
+	r.closers = append(r.closers, c)
 }
 
 func (r *RootCmd) Index() int {
@@ -52,6 +94,14 @@ func (r *RootCmd) Port() int {
 	return r.port
 }
 
+// SecretCodec returns the SecretCodec selected by initSecretCodec, so a
+// caller building its own config.Provider[T] against ConfigSource() can
+// pass it to config.WithProviderSecretCodec and actually decrypt
+// `secret:"true"` fields instead of defaulting to config.PassthroughCodec{}.
+func (r *RootCmd) SecretCodec() config.SecretCodec {
+	return r.secretCodec
+}
+
 type CmdOpts struct {
 	loggerPrefixName string
 	configMap        map[string]any
@@ -69,7 +119,7 @@ func WithConfigMap(configMap map[string]any) func(*CmdOpts) {
 }
 
 func NewRootCmd(processName string, opts ...func(*CmdOpts)) *RootCmd {
-	rootCmd := &RootCmd{processName: processName}
+	rootCmd := &RootCmd{processName: processName, configOpts: opts}
 	cmd := cobra.Command{
 		Use:  "Start openIM chat application",
 		Long: fmt.Sprintf(`Start %s `, processName),
@@ -81,12 +131,19 @@ func NewRootCmd(processName string, opts ...func(*CmdOpts)) *RootCmd {
 	}
 	cmd.Flags().StringP(config.FlagConf, "c", "", "path of config directory")
 	cmd.Flags().IntP(config.FlagTransferIndex, "i", 0, "process startup sequence number")
+	cmd.Flags().Bool(disableConfigWatchFlag, false, "disable watching the config center for changes after startup")
+	cmd.Flags().Duration(shutdownTimeoutFlag, defaultShutdownTimeout, "how long to wait for registered Closer hooks to drain on shutdown")
 
 	rootCmd.Command = cmd
+	rootCmd.Command.AddCommand(newConfigCmd(rootCmd))
 	return rootCmd
 }
 
-func (r *RootCmd) initEtcd() error {
+// initConfigStore selects and connects the config-center backend named by
+// config.Discovery.Enable. Any discovery backend that has a ConfigStore
+// implementation (etcd, Consul, Kubernetes, Nacos) can serve as the config
+// center; r.configStore stays nil for backends that don't.
+func (r *RootCmd) initConfigStore() error {
 	configDirectory, _, err := r.getFlag(&r.Command)
 	if err != nil {
 		return err
@@ -98,34 +155,82 @@ func (r *RootCmd) initEtcd() error {
 	if err != nil {
 		return err
 	}
-	if disConfig.Enable == kdisc.ETCDCONST {
-		discov, _ := kdisc.NewDiscoveryRegister(&disConfig, env, nil)
-		r.etcdClient = discov.(*etcd.SvcDiscoveryRegistryImpl).GetClient()
+	store, err := kdisc.NewConfigStore(&disConfig, env)
+	if err != nil {
+		log.ZWarn(context.Background(), "root cmd initConfigStore, no config store for this discovery backend: %v", errs.Wrap(err))
+		return nil
 	}
+	r.configStore = store
 	return nil
 }
 
+// secretCodecEnvVar selects which config.SecretCodec guards `secret:"true"`
+// fields in the config center. Unset or "none" keeps them plaintext.
+const secretCodecEnvVar = "CHAT_CONFIG_SECRET_CODEC"
+
+// initSecretCodec picks the SecretCodec named by secretCodecEnvVar.
+// Leaving it unset is the common case and preserves the old plaintext
+// behaviour.
+func (r *RootCmd) initSecretCodec() error {
+	codec, err := secretCodecFromEnv(secretCodecEnvVar)
+	if err != nil {
+		return err
+	}
+	r.secretCodec = codec
+	return nil
+}
+
+// secretCodecFromEnv builds the SecretCodec named by the value of envVar,
+// the same switch initSecretCodec uses for secretCodecEnvVar. It's exposed
+// so callers that need a second, independently-selected codec - e.g.
+// "config migrate-encrypt" decrypting with the codec a value was
+// originally written under before re-encrypting it with the current one -
+// can reuse the same env-var vocabulary under a different var name.
+func secretCodecFromEnv(envVar string) (config.SecretCodec, error) {
+	switch os.Getenv(envVar) {
+	case "", "none":
+		return config.PassthroughCodec{}, nil
+	case "aes-gcm":
+		return config.NewAESGCMCodecFromEnv("CHAT_CONFIG_SECRET_KEY")
+	case "vault":
+		return config.NewVaultTransitCodecFromEnv()
+	case "kms":
+		return config.NewKMSCodecFromEnv(context.Background())
+	default:
+		return nil, errs.New("unknown " + envVar + " value").Wrap()
+	}
+}
+
 func (r *RootCmd) persistentPreRun(cmd *cobra.Command, opts ...func(*CmdOpts)) error {
-	if err := r.initEtcd(); err != nil {
+	if err := r.initConfigStore(); err != nil {
+		return err
+	}
+	if err := r.initSecretCodec(); err != nil {
 		return err
 	}
 	cmdOpts := r.applyOptions(opts...)
 	if err := r.initializeConfiguration(cmd, cmdOpts); err != nil {
 		return err
 	}
-	if err := r.updateConfigFromEtcd(cmdOpts); err != nil {
+	if _, err := r.updateConfigFromStore(cmdOpts, true); err != nil {
 		return err
 	}
-	if err := r.initializeLogger(cmdOpts); err != nil {
+	r.loggerPrefixName = cmdOpts.loggerPrefixName
+	if err := r.initializeLogger(); err != nil {
 		return errs.WrapMsg(err, "failed to initialize logger")
 	}
-	if err := r.etcdClient.Close(); err != nil {
-		return errs.WrapMsg(err, "failed to close etcd client")
+	if err := r.startConfigWatch(cmd, cmdOpts); err != nil {
+		return errs.WrapMsg(err, "failed to start config watch")
 	}
 
 	return nil
 }
 
+// initializeConfiguration loads every config registered via WithConfigMap
+// plus the log config, validating each against its `validate` struct tags
+// as it goes. Until the real config types this series touches actually
+// carry those tags, config.Validate has nothing to reject here - adding
+// them is what turns this into the fail-fast schema check it's meant to be.
 func (r *RootCmd) initializeConfiguration(cmd *cobra.Command, opts *CmdOpts) error {
 	configDirectory, _, err := r.getFlag(cmd)
 	if err != nil {
@@ -143,68 +248,248 @@ func (r *RootCmd) initializeConfiguration(cmd *cobra.Command, opts *CmdOpts) err
 		if err != nil {
 			return err
 		}
+		if err := config.Validate(configStruct); err != nil {
+			return errs.WrapMsg(err, "invalid "+configFileName)
+		}
 	}
 	// Load common log configuration file
-	return config.Load(configDirectory, config.LogConfigFileName,
-		config.EnvPrefixMap[config.LogConfigFileName], runtimeEnv, &r.log)
+	if err := config.Load(configDirectory, config.LogConfigFileName,
+		config.EnvPrefixMap[config.LogConfigFileName], runtimeEnv, &r.log); err != nil {
+		return err
+	}
+	return errs.WrapMsg(config.Validate(&r.log), "invalid "+config.LogConfigFileName)
 }
 
-func (r *RootCmd) updateConfigFromEtcd(opts *CmdOpts) error {
-	if r.etcdClient == nil {
-		return nil
+// ConfigSource returns the layered file+config-store config.Source backing
+// this process, so callers can build a config.Provider[T] - pairing it with
+// SecretCodec() via config.WithProviderSecretCodec so encrypted fields keep
+// decoding correctly - and pass it down through their own constructors
+// instead of reaching into CmdOpts.configMap. The etcd layer is only
+// attached when the config center is backed by etcd and has been confirmed
+// enabled by updateConfigFromStore; other ConfigStore backends aren't yet
+// wired into config.Source and fall back to file-only.
+func (r *RootCmd) ConfigSource() config.Source {
+	src := &config.LayeredSource{
+		File: &config.FileSource{
+			Directory:  r.configPath,
+			RuntimeEnv: runtimeenv.PrintRuntimeEnvironment(),
+		},
+	}
+	if etcdStore, ok := r.configStore.(*disetcd.ConfigStore); r.configCenterEnabled && ok {
+		src.Etcd = &config.EtcdSource{Client: etcdStore.Client, Codec: r.secretCodec}
+	}
+	return src
+}
+
+// updateConfigFromStore mirrors the old updateConfigFromEtcd, but against
+// the kdisc.ConfigStore interface so it works the same way regardless of
+// which discovery backend is providing the config center. The returned map
+// holds the before/after snapshot of every config it actually decoded a
+// store value into, keyed by config file name, so a caller running this
+// outside the initial startup path (reloadConfig on SIGHUP) can pass the
+// real old value to config.Notify instead of faking one.
+//
+// initial must be true only for the one call made from persistentPreRun
+// before anything else can read opts.configMap, and for the one-shot CLI
+// commands in config.go that never hand their cmdOpts to a running server.
+// That's the only time it's safe for a store value to land directly in the
+// struct pointers a service registered via WithConfigMap: those pointers
+// are read by request-handling goroutines with no locking at all, so a
+// later SIGHUP reload (initial=false) must not touch them either - see
+// applyStoreValue.
+func (r *RootCmd) updateConfigFromStore(opts *CmdOpts, initial bool) (map[string][2]any, error) {
+	if r.configStore == nil {
+		return nil, nil
 	}
 	ctx := context.TODO()
 
-	res, err := r.etcdClient.Get(ctx, disetcd.BuildKey(disetcd.EnableConfigCenterKey))
+	enableVal, err := r.configStore.Get(ctx, disetcd.EnableConfigCenterKey)
 	if err != nil {
-		log.ZWarn(ctx, "root cmd updateConfigFromEtcd, etcd Get EnableConfigCenterKey err: %v", errs.Wrap(err))
-		return nil
+		log.ZWarn(ctx, "root cmd updateConfigFromStore, Get EnableConfigCenterKey err: %v", errs.Wrap(err))
+		return nil, nil
 	}
-	if res.Count == 0 {
-		return nil
-	} else {
-		if string(res.Kvs[0].Value) == disetcd.Disable {
-			return nil
-		} else if string(res.Kvs[0].Value) != disetcd.Enable {
-			return errs.New("unknown EnableConfigCenter value").Wrap()
-		}
+	if enableVal == nil {
+		return nil, nil
+	} else if string(enableVal) == disetcd.Disable {
+		return nil, nil
+	} else if string(enableVal) != disetcd.Enable {
+		return nil, errs.New("unknown EnableConfigCenter value").Wrap()
 	}
+	r.configCenterEnabled = true
 
+	snapshots := make(map[string][2]any, len(opts.configMap)+1)
 	update := func(configFileName string, configStruct any) error {
-		key := disetcd.BuildKey(configFileName)
-		etcdRes, err := r.etcdClient.Get(ctx, key)
+		storeVal, err := r.configStore.Get(ctx, configFileName)
 		if err != nil {
-			log.ZWarn(ctx, "root cmd updateConfigFromEtcd, etcd Get err: %v", errs.Wrap(err))
+			log.ZWarn(ctx, "root cmd updateConfigFromStore, Get err: %v", errs.Wrap(err))
 			return nil
 		}
-		if etcdRes.Count == 0 {
-			data, err := json.Marshal(configStruct)
+		if storeVal == nil {
+			data, err := config.EncodeSecretFields(ctx, r.secretCodec, configStruct)
 			if err != nil {
 				return errs.ErrArgs.WithDetail(err.Error()).Wrap()
 			}
-			_, err = r.etcdClient.Put(ctx, disetcd.BuildKey(configFileName), string(data))
-			if err != nil {
-				log.ZWarn(ctx, "root cmd updateConfigFromEtcd, etcd Put err: %v", errs.Wrap(err))
+			if err := r.configStore.Put(ctx, configFileName, data); err != nil {
+				log.ZWarn(ctx, "root cmd updateConfigFromStore, Put err: %v", errs.Wrap(err))
 			}
 			return nil
 		}
-		err = json.Unmarshal(etcdRes.Kvs[0].Value, configStruct)
+		mutate := initial || configFileName == config.LogConfigFileName
+		old, new, err := r.applyStoreValue(ctx, configFileName, configStruct, storeVal, mutate)
 		if err != nil {
-			return errs.WrapMsg(err, "failed to unmarshal config from etcd")
+			return errs.WrapMsg(err, "failed to decode config from config store")
 		}
+		snapshots[configFileName] = [2]any{old, new}
 		return nil
 	}
 	for configFileName, configStruct := range opts.configMap {
 		if err := update(configFileName, configStruct); err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if err := update(config.LogConfigFileName, &r.log); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// startConfigWatch keeps the config store's connection open and, unless
+// disabled via --disable-config-watch, opens a watch on every config key
+// the caller registered so that in-cluster edits are picked up without a
+// restart. When the config center is disabled or watching is turned off, it
+// falls back to closing the config store the way persistentPreRun used to
+// close the etcd client.
+func (r *RootCmd) startConfigWatch(cmd *cobra.Command, opts *CmdOpts) error {
+	if r.configStore == nil || !r.configCenterEnabled {
+		return r.closeConfigStore()
+	}
+	disabled, err := cmd.Flags().GetBool(disableConfigWatchFlag)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if disabled {
+		return r.closeConfigStore()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for configFileName, configStruct := range opts.configMap {
+		if err := r.watchConfigKey(ctx, configFileName, configStruct); err != nil {
+			return err
+		}
+	}
+	if err := r.watchConfigKey(ctx, config.LogConfigFileName, &r.log); err != nil {
 		return err
 	}
-	// Load common log configuration file
+	r.RegisterCloser(CloserFunc(func(context.Context) error {
+		cancel()
+		return r.closeConfigStore()
+	}))
 	return nil
+}
+
+func (r *RootCmd) closeConfigStore() error {
+	closer, ok := r.configStore.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return errs.WrapMsg(closer.Close(), "failed to close config store")
+}
 
+// watchConfigKey watches the config center key for configFileName and keeps
+// configStruct up to date for as long as ctx is alive.
+func (r *RootCmd) watchConfigKey(ctx context.Context, configFileName string, configStruct any) error {
+	return r.configStore.Watch(ctx, configFileName, func(data []byte) {
+		r.applyConfigUpdate(configFileName, configStruct, data)
+	})
+}
+
+// applyConfigUpdate decodes data and notifies subscribers. config.LogConfigFileName
+// is the one key still mutated in place (see applyStoreValue), so its
+// logger gets re-initialized here since it isn't reachable through
+// Subscribe; every other key only ever updates config.Notify's subscribers,
+// never the struct pointer a service registered via WithConfigMap.
+func (r *RootCmd) applyConfigUpdate(configFileName string, configStruct any, data []byte) {
+	ctx := context.Background()
+
+	mutate := configFileName == config.LogConfigFileName
+	old, new, err := r.applyStoreValue(ctx, configFileName, configStruct, data, mutate)
+	if err != nil {
+		log.ZWarn(ctx, "root cmd applyConfigUpdate, decode err: %v", errs.Wrap(err), "configFileName", configFileName)
+		return
+	}
+
+	if mutate {
+		if err := r.initializeLogger(); err != nil {
+			log.ZWarn(ctx, "root cmd applyConfigUpdate, re-init logger err: %v", errs.Wrap(err), "configFileName", configFileName)
+		}
+	}
+	config.Notify(configFileName, old, new)
+}
+
+// applyStoreValue decodes storeVal and returns the before/after snapshot
+// for the caller to pass to config.Notify.
+//
+// When mutate is true - config.LogConfigFileName, whose fields are read
+// solely by initializeLogger under this same call chain, or any config
+// during the initial load (updateConfigFromStore with initial=true) - it
+// decodes straight into configStruct under r.configMu. When mutate is
+// false - every config registered via WithConfigMap once that initial load
+// is done - it decodes into a
+// disposable clone instead and leaves configStruct exactly as
+// initializeConfiguration/updateConfigFromStore's initial load left it: the
+// services that registered those pointers read them directly with no lock
+// of their own, so mutating them again after startup would be a data race
+// no amount of locking on this side can fix. Those services pick up later
+// changes by adopting config.Subscribe or config.Provider[T] instead.
+func (r *RootCmd) applyStoreValue(ctx context.Context, configFileName string, configStruct any, storeVal []byte, mutate bool) (old, new any, err error) {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	if mutate {
+		old = configSnapshot(configStruct)
+		if err := config.DecodeSecretFields(ctx, r.secretCodec, storeVal, configStruct); err != nil {
+			return old, old, err
+		}
+		return old, configSnapshot(configStruct), nil
+	}
+
+	if last, ok := r.lastSnapshots[configFileName]; ok {
+		old = last
+	} else {
+		old = configSnapshot(configStruct)
+	}
+	clone := cloneConfigStruct(configStruct)
+	if err := config.DecodeSecretFields(ctx, r.secretCodec, storeVal, clone); err != nil {
+		return old, old, err
+	}
+	new = configSnapshot(clone)
+	if r.lastSnapshots == nil {
+		r.lastSnapshots = make(map[string]any)
+	}
+	r.lastSnapshots[configFileName] = new
+	return old, new, nil
+}
+
+// cloneConfigStruct returns a fresh *T zero value for the type behind
+// configStruct (a *T), for applyStoreValue to decode a runtime update into
+// without touching the shared original.
+func cloneConfigStruct(configStruct any) any {
+	t := reflect.TypeOf(configStruct)
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return configStruct
+}
+
+// configSnapshot returns the dereferenced value behind a *T config pointer
+// so subscribers get an immutable copy rather than a pointer that keeps
+// changing under them.
+func configSnapshot(configStruct any) any {
+	v := reflect.ValueOf(configStruct)
+	if v.Kind() == reflect.Ptr {
+		return v.Elem().Interface()
+	}
+	return configStruct
 }
 
 func (r *RootCmd) applyOptions(opts ...func(*CmdOpts)) *CmdOpts {
@@ -216,9 +501,9 @@ func (r *RootCmd) applyOptions(opts ...func(*CmdOpts)) *CmdOpts {
 	return cmdOpts
 }
 
-func (r *RootCmd) initializeLogger(cmdOpts *CmdOpts) error {
+func (r *RootCmd) initializeLogger() error {
 	err := log.InitLoggerFromConfig(
-		cmdOpts.loggerPrefixName,
+		r.loggerPrefixName,
 		r.processName,
 		"", "",
 		r.log.RemainLogLevel,
@@ -256,6 +541,100 @@ func (r *RootCmd) getFlag(cmd *cobra.Command) (string, int, error) {
 	return configDirectory, index, nil
 }
 
+// Execute runs the command tree under a root context that's cancelled on
+// SIGINT/SIGTERM, and reloaded in place on SIGHUP. Once the command returns,
+// it waits up to --shutdown-timeout for every Closer registered via
+// RegisterCloser to drain before returning.
 func (r *RootCmd) Execute() error {
-	return r.Command.Execute()
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Command.SetContext(ctx)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	go r.handleSignals(ctx, cancel, sigCh)
+
+	err := r.Command.Execute()
+	cancel()
+	r.waitForClosers()
+	return err
+}
+
+// handleSignals reloads the config on SIGHUP and cancels ctx on
+// SIGINT/SIGTERM, returning once ctx is done so Execute's deferred
+// signal.Stop can run.
+func (r *RootCmd) handleSignals(ctx context.Context, cancel context.CancelFunc, sigCh <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigCh:
+			switch sig {
+			case syscall.SIGHUP:
+				if err := r.reloadConfig(); err != nil {
+					log.ZWarn(ctx, "root cmd handleSignals, reloadConfig err: %v", errs.Wrap(err))
+				}
+			case syscall.SIGINT, syscall.SIGTERM:
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// reloadConfig re-reads the config center and notifies subscribers, the same
+// way applyConfigUpdate does for a watched key, but for every config at once.
+// It's the SIGHUP counterpart to the per-key watch started in
+// startConfigWatch, so it passes initial=false to updateConfigFromStore the
+// same way: only config.LogConfigFileName is mutated in place, everything
+// else only flows to config.Notify's subscribers.
+func (r *RootCmd) reloadConfig() error {
+	cmdOpts := r.applyOptions(r.configOpts...)
+	snapshots, err := r.updateConfigFromStore(cmdOpts, false)
+	if err != nil {
+		return err
+	}
+	if err := r.initializeLogger(); err != nil {
+		return errs.WrapMsg(err, "failed to re-initialize logger")
+	}
+	for configFileName, snapshot := range snapshots {
+		config.Notify(configFileName, snapshot[0], snapshot[1])
+	}
+	return nil
+}
+
+// waitForClosers drains every Closer registered via RegisterCloser, giving
+// up after --shutdown-timeout (defaultShutdownTimeout if the flag wasn't
+// set or is invalid).
+func (r *RootCmd) waitForClosers() {
+	if len(r.closers) == 0 {
+		return
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), r.shutdownTimeout())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, closer := range r.closers {
+			if err := closer.Close(shutdownCtx); err != nil {
+				log.ZWarn(shutdownCtx, "root cmd waitForClosers, Close err: %v", errs.Wrap(err))
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		log.ZWarn(shutdownCtx, "root cmd waitForClosers, timed out waiting for closers to drain: %v", errs.Wrap(shutdownCtx.Err()))
+	}
+}
+
+func (r *RootCmd) shutdownTimeout() time.Duration {
+	timeout, err := r.Command.Flags().GetDuration(shutdownTimeoutFlag)
+	if err != nil || timeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return timeout
 }
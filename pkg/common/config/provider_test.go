@@ -0,0 +1,173 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// fakeSource is a minimal in-memory Source, standing in for FileSource and
+// EtcdSource in tests the way chunk0-2's Source interface was introduced
+// to allow. set stores a marshaled value and, if Watch has already been
+// called for name, pushes it straight to the registered callback; push
+// does the same with pre-encoded bytes.
+type fakeSource struct {
+	mu       sync.Mutex
+	values   map[string][]byte
+	watchers map[string]func(data []byte)
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{values: map[string][]byte{}, watchers: map[string]func(data []byte){}}
+}
+
+func (s *fakeSource) set(name string, v any) {
+	data, _ := json.Marshal(v)
+	s.push(name, data)
+}
+
+func (s *fakeSource) push(name string, data []byte) {
+	s.mu.Lock()
+	s.values[name] = data
+	onChange := s.watchers[name]
+	s.mu.Unlock()
+	if onChange != nil {
+		onChange(data)
+	}
+}
+
+func (s *fakeSource) Load(_ context.Context, name string, v any) error {
+	s.mu.Lock()
+	data, ok := s.values[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrConfigNotFound
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *fakeSource) Watch(_ context.Context, name string, onChange func(data []byte)) error {
+	s.mu.Lock()
+	s.watchers[name] = onChange
+	s.mu.Unlock()
+	return nil
+}
+
+type providerTestConfig struct {
+	Greeting string `json:"greeting"`
+}
+
+func TestProvider_GetAndReload(t *testing.T) {
+	ctx := context.Background()
+	src := newFakeSource()
+	src.set("greeting.yml", providerTestConfig{Greeting: "hello"})
+
+	p, err := NewProvider[providerTestConfig](ctx, "greeting.yml", src)
+	if err != nil {
+		t.Fatalf("NewProvider() err = %v", err)
+	}
+	if got := p.Get().Greeting; got != "hello" {
+		t.Fatalf("Get().Greeting = %q, want %q", got, "hello")
+	}
+
+	src.set("greeting.yml", providerTestConfig{Greeting: "goodbye"})
+	if err := p.Reload(ctx); err != nil {
+		t.Fatalf("Reload() err = %v", err)
+	}
+	if got := p.Get().Greeting; got != "goodbye" {
+		t.Fatalf("Get().Greeting after Reload() = %q, want %q", got, "goodbye")
+	}
+}
+
+func TestProvider_Watch(t *testing.T) {
+	ctx := context.Background()
+	src := newFakeSource()
+	src.set("greeting.yml", providerTestConfig{Greeting: "hello"})
+
+	p, err := NewProvider[providerTestConfig](ctx, "greeting.yml", src)
+	if err != nil {
+		t.Fatalf("NewProvider() err = %v", err)
+	}
+	if err := p.Watch(ctx); err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+
+	src.set("greeting.yml", providerTestConfig{Greeting: "watched"})
+	if got := p.Get().Greeting; got != "watched" {
+		t.Fatalf("Get().Greeting after a pushed change = %q, want %q", got, "watched")
+	}
+}
+
+// TestProvider_WatchDecryptsSecretFields guards against the bug where
+// Provider.Watch decoded pushed updates with a bare json.Unmarshal instead
+// of DecodeSecretFields, so a Provider[T] consumer -- the DI pattern
+// chunk0-2 asked services to adopt instead of CmdOpts.configMap -- would
+// see base64 ciphertext in a `secret:"true"` field instead of the real
+// value once encryption was turned on.
+func TestProvider_WatchDecryptsSecretFields(t *testing.T) {
+	ctx := context.Background()
+	src := newFakeSource()
+	src.set("secret.yml", secretTestStruct{Name: "svc"})
+
+	codec := &AESGCMCodec{Key: make([]byte, 32)}
+	p, err := NewProvider[secretTestStruct](ctx, "secret.yml", src, WithProviderSecretCodec[secretTestStruct](codec))
+	if err != nil {
+		t.Fatalf("NewProvider() err = %v", err)
+	}
+	if err := p.Watch(ctx); err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+
+	encoded, err := EncodeSecretFields(ctx, codec, &secretTestStruct{Name: "svc", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("EncodeSecretFields() err = %v", err)
+	}
+	src.push("secret.yml", encoded)
+
+	if got := p.Get().Password; got != "hunter2" {
+		t.Fatalf("Get().Password after a pushed secret update = %q, want %q", got, "hunter2")
+	}
+}
+
+func TestProvider_ReloadNotifiesSubscribers(t *testing.T) {
+	ctx := context.Background()
+	src := newFakeSource()
+	src.set("notify.yml", providerTestConfig{Greeting: "hello"})
+
+	p, err := NewProvider[providerTestConfig](ctx, "notify.yml", src)
+	if err != nil {
+		t.Fatalf("NewProvider() err = %v", err)
+	}
+
+	var gotOld, gotNew any
+	Subscribe("notify.yml", func(old, new any) {
+		gotOld, gotNew = old, new
+	})
+
+	src.set("notify.yml", providerTestConfig{Greeting: "goodbye"})
+	if err := p.Reload(ctx); err != nil {
+		t.Fatalf("Reload() err = %v", err)
+	}
+
+	if gotOld != (providerTestConfig{Greeting: "hello"}) {
+		t.Fatalf("subscriber old = %+v, want %+v", gotOld, providerTestConfig{Greeting: "hello"})
+	}
+	if gotNew != (providerTestConfig{Greeting: "goodbye"}) {
+		t.Fatalf("subscriber new = %+v, want %+v", gotNew, providerTestConfig{Greeting: "goodbye"})
+	}
+}
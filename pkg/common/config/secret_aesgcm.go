@@ -0,0 +1,88 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"os"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+// AESGCMCodec implements SecretCodec with AES-GCM, keyed by a raw
+// 16/24/32-byte Key (NewAESGCMCodecFromEnv decodes it from base64). A
+// fresh nonce is generated per Encrypt call and
+// prepended to the returned ciphertext.
+type AESGCMCodec struct {
+	Key []byte
+}
+
+// NewAESGCMCodecFromEnv reads a base64-encoded AES key from envVar.
+func NewAESGCMCodecFromEnv(envVar string) (*AESGCMCodec, error) {
+	encoded := os.Getenv(envVar)
+	if encoded == "" {
+		return nil, errs.New("config: " + envVar + " is not set").Wrap()
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "invalid AES key encoding")
+	}
+	return &AESGCMCodec{Key: key}, nil
+}
+
+func (c *AESGCMCodec) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *AESGCMCodec) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errs.New("config: AES-GCM ciphertext shorter than nonce").Wrap()
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return plaintext, nil
+}
+
+func (c *AESGCMCodec) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return gcm, nil
+}
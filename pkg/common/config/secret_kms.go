@@ -0,0 +1,68 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"os"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+// KMSCodec implements SecretCodec by calling AWS KMS Encrypt/Decrypt on
+// each secret field directly with KeyID. No data key is generated, so this
+// is not envelope encryption.
+type KMSCodec struct {
+	Client *kms.Client
+	KeyID  string
+}
+
+// NewKMSCodecFromEnv loads the default AWS SDK config (region, creds from
+// the usual chain) and the key named by AWS_KMS_KEY_ID.
+func NewKMSCodecFromEnv(ctx context.Context) (*KMSCodec, error) {
+	keyID := os.Getenv("AWS_KMS_KEY_ID")
+	if keyID == "" {
+		return nil, errs.New("config: AWS_KMS_KEY_ID is not set").Wrap()
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return &KMSCodec{Client: kms.NewFromConfig(cfg), KeyID: keyID}, nil
+}
+
+func (c *KMSCodec) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	out, err := c.Client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:     &c.KeyID,
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (c *KMSCodec) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	out, err := c.Client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,99 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"testing"
+)
+
+type secretTestStruct struct {
+	Name     string `json:"name"`
+	Password string `json:"password" secret:"true"`
+}
+
+func TestEncodeDecodeSecretFields_Passthrough(t *testing.T) {
+	ctx := context.Background()
+	codec := PassthroughCodec{}
+
+	v := &secretTestStruct{Name: "svc", Password: "hunter2"}
+	data, err := EncodeSecretFields(ctx, codec, v)
+	if err != nil {
+		t.Fatalf("EncodeSecretFields() err = %v", err)
+	}
+	if v.Password != "hunter2" {
+		t.Fatalf("EncodeSecretFields() mutated the input, Password = %q", v.Password)
+	}
+
+	var got secretTestStruct
+	if err := DecodeSecretFields(ctx, codec, data, &got); err != nil {
+		t.Fatalf("DecodeSecretFields() err = %v", err)
+	}
+	if got != *v {
+		t.Fatalf("round-trip = %+v, want %+v", got, *v)
+	}
+}
+
+func TestEncodeDecodeSecretFields_AESGCM(t *testing.T) {
+	ctx := context.Background()
+	codec := &AESGCMCodec{Key: make([]byte, 32)}
+
+	v := &secretTestStruct{Name: "svc", Password: "hunter2"}
+	data, err := EncodeSecretFields(ctx, codec, v)
+	if err != nil {
+		t.Fatalf("EncodeSecretFields() err = %v", err)
+	}
+
+	var got secretTestStruct
+	if err := DecodeSecretFields(ctx, codec, data, &got); err != nil {
+		t.Fatalf("DecodeSecretFields() err = %v", err)
+	}
+	if got != *v {
+		t.Fatalf("round-trip = %+v, want %+v", got, *v)
+	}
+
+	// A codec that didn't actually encrypt the field would leave the stored
+	// JSON holding the plaintext password verbatim.
+	if containsPlaintext(data, v.Password) {
+		t.Fatalf("encoded data contains the plaintext password: %s", data)
+	}
+}
+
+func TestDecodeSecretFields_WrongCodecFails(t *testing.T) {
+	ctx := context.Background()
+	data, err := EncodeSecretFields(ctx, &AESGCMCodec{Key: make([]byte, 32)}, &secretTestStruct{Name: "svc", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("EncodeSecretFields() err = %v", err)
+	}
+
+	var got secretTestStruct
+	wrongKey := make([]byte, 32)
+	wrongKey[0] = 1
+	if err := DecodeSecretFields(ctx, &AESGCMCodec{Key: wrongKey}, data, &got); err == nil {
+		t.Fatal("DecodeSecretFields() with the wrong key = nil, want error")
+	}
+}
+
+func containsPlaintext(data []byte, plaintext string) bool {
+	for i := range data {
+		if i+len(plaintext) > len(data) {
+			break
+		}
+		if string(data[i:i+len(plaintext)]) == plaintext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+// VaultTransitCodec implements SecretCodec via Vault's Transit secrets
+// engine, so the encryption key never has to leave Vault.
+type VaultTransitCodec struct {
+	Client  *vaultapi.Client
+	KeyName string
+}
+
+// NewVaultTransitCodecFromEnv builds a client from the standard
+// VAULT_ADDR/VAULT_TOKEN environment variables and the transit key named
+// by VAULT_TRANSIT_KEY (defaulting to "openim-chat-config").
+func NewVaultTransitCodecFromEnv() (*VaultTransitCodec, error) {
+	client, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	keyName := os.Getenv("VAULT_TRANSIT_KEY")
+	if keyName == "" {
+		keyName = "openim-chat-config"
+	}
+	return &VaultTransitCodec{Client: client, KeyName: keyName}, nil
+}
+
+func (c *VaultTransitCodec) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	secret, err := c.Client.Logical().WriteWithContext(ctx, "transit/encrypt/"+c.KeyName, map[string]any{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	ciphertext, _ := secret.Data["ciphertext"].(string)
+	return []byte(ciphertext), nil
+}
+
+func (c *VaultTransitCodec) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	secret, err := c.Client.Logical().WriteWithContext(ctx, "transit/decrypt/"+c.KeyName, map[string]any{
+		"ciphertext": string(ciphertext),
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	encoded, _ := secret.Data["plaintext"].(string)
+	return base64.StdEncoding.DecodeString(encoded)
+}
@@ -0,0 +1,49 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "sync"
+
+// ChangeFunc is invoked with the previous and current value of a config
+// whenever it is reloaded from the config center. old is the value held
+// before the reload (for a Provider's first load, the zero value of its T).
+type ChangeFunc func(old, new any)
+
+var (
+	subMu sync.RWMutex
+	subs  = make(map[string][]ChangeFunc)
+)
+
+// Subscribe registers fn to be called whenever the config identified by name
+// (a config file name such as config.LogConfigFileName) is reloaded. name
+// does not need to exist yet; callbacks are simply never invoked until a
+// matching config is loaded.
+func Subscribe(name string, fn func(old, new any)) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	subs[name] = append(subs[name], fn)
+}
+
+// Notify fans out a config change to every subscriber registered for name.
+// It is called by the config-loading subsystem and is not normally called
+// directly by services.
+func Notify(name string, old, new any) {
+	subMu.RLock()
+	fns := append([]ChangeFunc(nil), subs[name]...)
+	subMu.RUnlock()
+	for _, fn := range fns {
+		fn(old, new)
+	}
+}
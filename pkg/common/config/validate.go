@@ -0,0 +1,87 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+// Validate walks v (a pointer to a config struct) and enforces the rules
+// encoded in its `validate` struct tags, so a bad config fails fast at boot
+// with an actionable message instead of surfacing as a runtime nil-pointer
+// panic later. Recognized rules: "required" (zero value rejected), "port"
+// (int must be in [1, 65535]), "url" (non-empty string must parse as a
+// URL). Unknown tag values are ignored so existing config structs don't
+// need every field annotated before this starts being useful.
+func Validate(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil
+	}
+	return validateStruct(rv.Elem())
+}
+
+func validateStruct(rv reflect.Value) error {
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if err := applyValidateRule(rule, field.Name, fv); err != nil {
+				return err
+			}
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := validateStruct(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func applyValidateRule(rule, fieldName string, fv reflect.Value) error {
+	switch rule {
+	case "", "-":
+		return nil
+	case "required":
+		if fv.IsZero() {
+			return errs.New(fmt.Sprintf("config: %s is required", fieldName)).Wrap()
+		}
+	case "port":
+		port := fv.Int()
+		if port < 1 || port > 65535 {
+			return errs.New(fmt.Sprintf("config: %s must be a valid port (1-65535), got %d", fieldName, port)).Wrap()
+		}
+	case "url":
+		if fv.Kind() == reflect.String && fv.String() != "" {
+			if _, err := url.Parse(fv.String()); err != nil {
+				return errs.New(fmt.Sprintf("config: %s is not a valid URL: %v", fieldName, err)).Wrap()
+			}
+		}
+	}
+	return nil
+}
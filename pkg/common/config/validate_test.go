@@ -0,0 +1,55 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+type validateTestStruct struct {
+	Name string `validate:"required"`
+	Port int    `validate:"port"`
+	URL  string `validate:"url"`
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		v       validateTestStruct
+		wantErr bool
+	}{
+		{"ok", validateTestStruct{Name: "svc", Port: 8080, URL: "http://example.com"}, false},
+		{"missing required", validateTestStruct{Port: 8080, URL: "http://example.com"}, true},
+		{"port too low", validateTestStruct{Name: "svc", Port: 0, URL: "http://example.com"}, true},
+		{"port too high", validateTestStruct{Name: "svc", Port: 70000, URL: "http://example.com"}, true},
+		{"bad url", validateTestStruct{Name: "svc", Port: 8080, URL: "://not-a-url"}, true},
+		{"empty url is not validated", validateTestStruct{Name: "svc", Port: 8080, URL: ""}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Validate(&c.v)
+			if c.wantErr && err == nil {
+				t.Fatalf("Validate() = nil, want error")
+			}
+			if !c.wantErr && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestValidate_NonPointerIsIgnored(t *testing.T) {
+	if err := Validate(validateTestStruct{}); err != nil {
+		t.Fatalf("Validate() on a non-pointer = %v, want nil", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdisc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openimsdk/chat/pkg/common/config"
+	"github.com/openimsdk/chat/pkg/common/kdisc/consul"
+	disetcd "github.com/openimsdk/chat/pkg/common/kdisc/etcd"
+	"github.com/openimsdk/chat/pkg/common/kdisc/k8s"
+	"github.com/openimsdk/chat/pkg/common/kdisc/nacos"
+	"github.com/openimsdk/tools/discovery/etcd"
+)
+
+const (
+	CONSULCONST     = "consul"
+	KUBERNETESCONST = "kubernetes"
+	NACOSCONST      = "nacos"
+)
+
+// ConfigStore abstracts a config-center backend: something that can fetch,
+// write, and watch a single named config blob. It sits next to
+// SvcDiscoveryRegistry so the config center and service discovery can be
+// backed by different systems, or the same one, without callers caring
+// which. Unlike SvcDiscoveryRegistry it is never required: NewConfigStore
+// returns an error when the discovery backend has no config-center
+// implementation, and callers may carry on without a config store.
+type ConfigStore interface {
+	// Get returns the current value of key, or (nil, nil) if key has
+	// never been written.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put writes value under key.
+	Put(ctx context.Context, key string, value []byte) error
+	// Watch calls onChange with the new value of key every time it
+	// changes, until ctx is cancelled.
+	Watch(ctx context.Context, key string, onChange func(value []byte)) error
+}
+
+// NewConfigStore builds the ConfigStore selected by conf.Enable, the same
+// field NewDiscoveryRegister already switches on. Operators who would
+// rather manage config through Consul KV, Kubernetes ConfigMaps, or Nacos
+// than stand up etcd solely as a config center for openim-chat can pick
+// any of those here instead.
+func NewConfigStore(conf *config.Discovery, env string) (ConfigStore, error) {
+	switch conf.Enable {
+	case ETCDCONST:
+		discov, err := NewDiscoveryRegister(conf, env, nil)
+		if err != nil {
+			return nil, err
+		}
+		return disetcd.NewConfigStore(discov.(*etcd.SvcDiscoveryRegistryImpl).GetClient()), nil
+	case CONSULCONST:
+		client, err := consul.NewClientFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return consul.NewConfigStore(client, consul.DefaultPrefix), nil
+	case KUBERNETESCONST:
+		client, namespace, configMap, err := k8s.NewClientFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return k8s.NewConfigStore(client, namespace, configMap), nil
+	case NACOSCONST:
+		client, err := nacos.NewClientFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return nacos.NewConfigStore(client, nacos.DefaultGroup), nil
+	default:
+		return nil, fmt.Errorf("kdisc: unsupported config store backend %q", conf.Enable)
+	}
+}
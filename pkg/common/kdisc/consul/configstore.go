@@ -0,0 +1,113 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package consul implements kdisc.ConfigStore on top of Consul's KV store,
+// for operators who run Consul for service discovery and would rather
+// reuse it as the openim-chat config center than also stand up etcd.
+package consul
+
+import (
+	"context"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+)
+
+// consulWatchRetryDelay is how long Watch waits before retrying a blocking
+// query after a transient error, so a Consul hiccup doesn't spin the
+// goroutine in a tight error loop.
+const consulWatchRetryDelay = time.Second
+
+// DefaultPrefix namespaces every openim-chat config key under the same
+// Consul KV path.
+const DefaultPrefix = "openim-chat/config/"
+
+// NewClientFromEnv builds a Consul API client from the standard
+// CONSUL_HTTP_ADDR / CONSUL_HTTP_TOKEN environment variables.
+func NewClientFromEnv() (*consulapi.Client, error) {
+	client, err := consulapi.NewClient(consulapi.DefaultConfig())
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return client, nil
+}
+
+// ConfigStore implements kdisc.ConfigStore on top of Consul's KV store.
+type ConfigStore struct {
+	KV     *consulapi.KV
+	Prefix string
+}
+
+func NewConfigStore(client *consulapi.Client, prefix string) *ConfigStore {
+	return &ConfigStore{KV: client.KV(), Prefix: prefix}
+}
+
+func (s *ConfigStore) key(key string) string {
+	return s.Prefix + key
+}
+
+func (s *ConfigStore) Get(ctx context.Context, key string) ([]byte, error) {
+	pair, _, err := s.KV.Get(s.key(key), (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if pair == nil {
+		return nil, nil
+	}
+	return pair.Value, nil
+}
+
+func (s *ConfigStore) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.KV.Put(&consulapi.KVPair{Key: s.key(key), Value: value}, (&consulapi.WriteOptions{}).WithContext(ctx))
+	return errs.Wrap(err)
+}
+
+// Watch long-polls Consul's blocking queries for changes to key, calling
+// onChange whenever the KV entry's ModifyIndex advances. A transient Get
+// error (a momentarily unreachable Consul agent, a reset connection) is
+// logged and retried after consulWatchRetryDelay rather than silently
+// ending the watch.
+func (s *ConfigStore) Watch(ctx context.Context, key string, onChange func(value []byte)) error {
+	go func() {
+		var lastIndex uint64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			pair, meta, err := s.KV.Get(s.key(key), (&consulapi.QueryOptions{
+				WaitIndex: lastIndex,
+			}).WithContext(ctx))
+			if err != nil {
+				log.ZWarn(ctx, "consul configstore Watch, Get err, retrying", errs.Wrap(err), "key", key)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(consulWatchRetryDelay):
+				}
+				continue
+			}
+			if meta.LastIndex != lastIndex {
+				lastIndex = meta.LastIndex
+				if pair != nil {
+					onChange(pair.Value)
+				}
+			}
+		}
+	}()
+	return nil
+}
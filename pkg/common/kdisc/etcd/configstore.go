@@ -0,0 +1,92 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ConfigStore implements kdisc.ConfigStore on top of an existing etcd
+// client, storing every key under BuildKey(key) the same way root.go used
+// to do inline.
+type ConfigStore struct {
+	Client *clientv3.Client
+}
+
+func NewConfigStore(client *clientv3.Client) *ConfigStore {
+	return &ConfigStore{Client: client}
+}
+
+func (s *ConfigStore) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := s.Client.Get(ctx, BuildKey(key))
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if res.Count == 0 {
+		return nil, nil
+	}
+	return res.Kvs[0].Value, nil
+}
+
+func (s *ConfigStore) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.Client.Put(ctx, BuildKey(key), string(value))
+	return errs.Wrap(err)
+}
+
+// Watch keeps a watch open on BuildKey(key) for as long as ctx is alive,
+// re-establishing it whenever the watch channel closes or reports an error
+// (a compaction or a canceled watch both surface this way) instead of
+// silently giving up on hot-reload for that key.
+func (s *ConfigStore) Watch(ctx context.Context, key string, onChange func(value []byte)) error {
+	go func() {
+		for ctx.Err() == nil {
+			watchCh := s.Client.Watch(ctx, BuildKey(key))
+			for resp := range watchCh {
+				if err := resp.Err(); err != nil {
+					log.ZWarn(ctx, "etcd configstore Watch, watch response err, re-establishing", errs.Wrap(err), "key", key)
+					break
+				}
+				for _, event := range resp.Events {
+					if event.Type == clientv3.EventTypePut {
+						onChange(event.Kv.Value)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// PutAll writes every key in values atomically via a single etcd
+// transaction, so a migration either fully lands or fully rolls back.
+func (s *ConfigStore) PutAll(ctx context.Context, values map[string][]byte) error {
+	ops := make([]clientv3.Op, 0, len(values))
+	for key, value := range values {
+		ops = append(ops, clientv3.OpPut(BuildKey(key), string(value)))
+	}
+	_, err := s.Client.Txn(ctx).Then(ops...).Commit()
+	return errs.Wrap(err)
+}
+
+// Close releases the underlying etcd client. Callers that obtained the
+// client from elsewhere (e.g. service discovery) and still need it after
+// the config store is done with it should not call this.
+func (s *ConfigStore) Close() error {
+	return errs.Wrap(s.Client.Close())
+}
@@ -0,0 +1,183 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8s implements kdisc.ConfigStore on top of a single Kubernetes
+// ConfigMap, one data key per openim-chat config file, for operators who
+// run on Kubernetes and would rather manage config with kubectl than stand
+// up etcd solely as a config center.
+package k8s
+
+import (
+	"context"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+)
+
+// k8sWatchRetryDelay is how long Watch waits before re-establishing the
+// apiserver watch after it closes, so a watch timeout (routine in any real
+// cluster) or a transient failure to open a new one doesn't spin the
+// goroutine in a tight loop.
+const k8sWatchRetryDelay = time.Second
+
+// DefaultConfigMapName is the ConfigMap openim-chat reads and writes its
+// config keys under when no override is given.
+const DefaultConfigMapName = "openim-chat-config"
+
+// NewClientFromEnv builds an in-cluster client, reading the namespace and
+// ConfigMap name to use from the POD_NAMESPACE env var (falling back to
+// "default") and CHAT_CONFIGMAP_NAME (falling back to DefaultConfigMapName).
+func NewClientFromEnv() (kubernetes.Interface, string, string, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, "", "", errs.Wrap(err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", "", errs.Wrap(err)
+	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+	configMap := os.Getenv("CHAT_CONFIGMAP_NAME")
+	if configMap == "" {
+		configMap = DefaultConfigMapName
+	}
+	return client, namespace, configMap, nil
+}
+
+// ConfigStore implements kdisc.ConfigStore on top of a single Kubernetes
+// ConfigMap.
+type ConfigStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	ConfigMap string
+}
+
+func NewConfigStore(client kubernetes.Interface, namespace, configMap string) *ConfigStore {
+	return &ConfigStore{Client: client, Namespace: namespace, ConfigMap: configMap}
+}
+
+func (s *ConfigStore) Get(ctx context.Context, key string) ([]byte, error) {
+	cm, err := s.Client.CoreV1().ConfigMaps(s.Namespace).Get(ctx, s.ConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	value, ok := cm.Data[key]
+	if !ok {
+		return nil, nil
+	}
+	return []byte(value), nil
+}
+
+func (s *ConfigStore) Put(ctx context.Context, key string, value []byte) error {
+	configMaps := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	cm, err := configMaps.Get(ctx, s.ConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.ConfigMap, Namespace: s.Namespace},
+			Data:       map[string]string{key: string(value)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return errs.Wrap(err)
+	}
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[key] = string(value)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return errs.Wrap(err)
+}
+
+// Watch opens a watch on this ConfigStore's ConfigMap and re-establishes it
+// whenever the apiserver closes the result channel - including the routine
+// timeout every Kubernetes watch eventually hits - instead of silently
+// stopping hot-reload for key after the first one.
+func (s *ConfigStore) Watch(ctx context.Context, key string, onChange func(value []byte)) error {
+	watcher, err := s.watch(ctx)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	go func() {
+		for {
+			s.drainWatch(ctx, watcher, key, onChange)
+			if ctx.Err() != nil {
+				return
+			}
+			log.ZWarn(ctx, "k8s configstore Watch, watch channel closed, re-establishing", errs.New("watch channel closed").Wrap(), "key", key)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(k8sWatchRetryDelay):
+			}
+			watcher, err = s.watch(ctx)
+			if err != nil {
+				log.ZWarn(ctx, "k8s configstore Watch, failed to re-establish watch, retrying", errs.Wrap(err), "key", key)
+				watcher = nil
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *ConfigStore) watch(ctx context.Context) (watch.Interface, error) {
+	return s.Client.CoreV1().ConfigMaps(s.Namespace).Watch(ctx, metav1.SingleObject(metav1.ObjectMeta{
+		Name:      s.ConfigMap,
+		Namespace: s.Namespace,
+	}))
+}
+
+// drainWatch forwards ConfigMap update events to onChange until watcher's
+// result channel closes or ctx is done. watcher may be nil if the previous
+// re-establish attempt failed, in which case it returns immediately so the
+// caller retries.
+func (s *ConfigStore) drainWatch(ctx context.Context, watcher watch.Interface, key string, onChange func(value []byte)) {
+	if watcher == nil {
+		return
+	}
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			cm, ok := event.Object.(*corev1.ConfigMap)
+			if !ok {
+				continue
+			}
+			if value, ok := cm.Data[key]; ok {
+				onChange([]byte(value))
+			}
+		}
+	}
+}
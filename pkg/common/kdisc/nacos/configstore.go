@@ -0,0 +1,134 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nacos implements kdisc.ConfigStore on top of a Nacos config
+// client, for operators who already run Nacos for service discovery and
+// would rather reuse it as the openim-chat config center than also stand
+// up etcd.
+package nacos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/clients"
+	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+func parseServerAddr(addr string) (constant.ServerConfig, error) {
+	host, portStr, ok := strings.Cut(addr, ":")
+	if !ok {
+		return constant.ServerConfig{}, fmt.Errorf("nacos: invalid NACOS_SERVER_ADDR %q, want host:port", addr)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 64)
+	if err != nil {
+		return constant.ServerConfig{}, fmt.Errorf("nacos: invalid NACOS_SERVER_ADDR port %q: %w", portStr, err)
+	}
+	return constant.ServerConfig{IpAddr: host, Port: port}, nil
+}
+
+// DefaultGroup is the Nacos group every openim-chat config key is
+// published under when no override is given.
+const DefaultGroup = "openim-chat"
+
+// NewClientFromEnv builds a Nacos config client from the NACOS_SERVER_ADDR
+// (host:port) and NACOS_NAMESPACE_ID environment variables.
+func NewClientFromEnv() (config_client.IConfigClient, error) {
+	serverConfig, err := parseServerAddr(os.Getenv("NACOS_SERVER_ADDR"))
+	if err != nil {
+		return nil, err
+	}
+	client, err := clients.CreateConfigClient(map[string]interface{}{
+		"serverConfigs": []constant.ServerConfig{serverConfig},
+		"clientConfig": constant.ClientConfig{
+			NamespaceId: os.Getenv("NACOS_NAMESPACE_ID"),
+		},
+	})
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	return client, nil
+}
+
+// ConfigStore implements kdisc.ConfigStore on top of a Nacos config
+// client, storing every openim-chat config under the same Group.
+type ConfigStore struct {
+	Client config_client.IConfigClient
+	Group  string
+}
+
+func NewConfigStore(client config_client.IConfigClient, group string) *ConfigStore {
+	return &ConfigStore{Client: client, Group: group}
+}
+
+func (s *ConfigStore) Get(_ context.Context, key string) ([]byte, error) {
+	content, err := s.Client.GetConfig(vo.ConfigParam{DataId: key, Group: s.Group})
+	if err != nil {
+		if isConfigNotFound(err) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	if content == "" {
+		return nil, nil
+	}
+	return []byte(content), nil
+}
+
+// isConfigNotFound reports whether err is nacos-sdk-go's "config data not
+// exist" response. Unlike etcd/Consul/Kubernetes, which report a missing
+// key as an empty result with no error, GetConfig surfaces it as an error,
+// so without this check root.go's updateConfigFromStore would treat every
+// missing key as a hard Get failure and skip seeding the default config on
+// first boot.
+//
+// nacos-sdk-go doesn't expose a structured error code for this response -
+// GetConfig returns a 404 from the server as a plain error built from the
+// literal string "config data not exist" - so that's the exact phrase
+// matched here, rather than the broader "not exist"/"not found" substrings
+// this used to check for, which a genuine connectivity or auth failure
+// could just as easily contain and get misread as "key absent".
+func isConfigNotFound(err error) bool {
+	return strings.Contains(err.Error(), "config data not exist")
+}
+
+func (s *ConfigStore) Put(_ context.Context, key string, value []byte) error {
+	_, err := s.Client.PublishConfig(vo.ConfigParam{DataId: key, Group: s.Group, Content: string(value)})
+	return errs.Wrap(err)
+}
+
+func (s *ConfigStore) Watch(ctx context.Context, key string, onChange func(value []byte)) error {
+	err := s.Client.ListenConfig(vo.ConfigParam{
+		DataId: key,
+		Group:  s.Group,
+		OnChange: func(namespace, group, dataId, data string) {
+			onChange([]byte(data))
+		},
+	})
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = s.Client.CancelListenConfig(vo.ConfigParam{DataId: key, Group: s.Group})
+	}()
+	return nil
+}